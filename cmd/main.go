@@ -1,24 +1,37 @@
 package main
 
 import (
+    "context"
     "flag"
     "fmt"
     "log"
     "os"
     "os/signal"
+    "path/filepath"
     "syscall"
 
+    "rinkokonoe/internal/cloud"
     "rinkokonoe/internal/config"
     "rinkokonoe/internal/database"
     "rinkokonoe/internal/discord"
-    "rinkokonoe/modules/infopulse"
-    "rinkokonoe/modules/specter"
-    "rinkokonoe/modules/validator"
+    "rinkokonoe/internal/guild"
+    "rinkokonoe/internal/logging"
+    "rinkokonoe/internal/modules"
+
+    // Blank-imported for its init() side effect: it registers itself with
+    // the modules package's Registry.
+    _ "rinkokonoe/modules/infopulse"
 )
 
+// pluginDir is where operators can drop compiled module .so files to load
+// additional scrapers without rebuilding this binary.
+const pluginDir = "plugins"
+
 func main() {
     // Parse command line flags
     configPath := flag.String("config", ".env", "Path to configuration file")
+    migrateTo := flag.Int("migrate-to", -1, "Migrate the database to this schema version and exit")
+    migrateStatus := flag.Bool("migrate-status", false, "Print applied/pending migrations and exit")
     flag.Parse()
 
     // Initialize configuration
@@ -27,39 +40,103 @@ func main() {
         log.Fatalf("Failed to load configuration: %v", err)
     }
 
+    // Initialize structured logging. This must happen before anything else
+    // logs, so use the stdlib logger for failures up to this point.
+    if err := logging.Init(cfg.LogDirectory, cfg.Environment); err != nil {
+        log.Fatalf("Failed to initialize logging: %v", err)
+    }
+    logger := logging.With("module", "main")
+
     // Initialize database
     db, err := database.New(cfg.DatabasePath)
     if err != nil {
-        log.Fatalf("Failed to initialize database: %v", err)
+        logger.Fatal().Err(err).Msg("failed to initialize database")
     }
     defer db.Close()
 
+    ctx := context.Background()
+
+    if *migrateStatus {
+        applied, pending, err := db.MigrationStatus(ctx)
+        if err != nil {
+            logger.Fatal().Err(err).Msg("failed to read migration status")
+        }
+        fmt.Println("Applied:")
+        for _, m := range applied {
+            fmt.Printf("  %s\n", m)
+        }
+        fmt.Println("Pending:")
+        for _, m := range pending {
+            fmt.Printf("  %s\n", m)
+        }
+        return
+    }
+
+    if *migrateTo >= 0 {
+        if err := db.MigrateTo(ctx, *migrateTo); err != nil {
+            logger.Fatal().Err(err).Int("version", *migrateTo).Msg("failed to migrate database")
+        }
+        fmt.Printf("Database migrated to version %d.\n", *migrateTo)
+        return
+    }
+
+    if err := db.MigrateUp(ctx); err != nil {
+        logger.Fatal().Err(err).Msg("failed to migrate database")
+    }
+
+    // shutdownCtx is cancelled once a shutdown signal arrives, so any
+    // in-flight rclone sync gets sent SIGTERM instead of being abandoned.
+    shutdownCtx, cancelShutdown := context.WithCancel(context.Background())
+    defer cancelShutdown()
+
+    // Initialize per-guild configuration store
+    guilds := guild.NewStore(db.GetDB())
+    if err := guilds.Load(); err != nil {
+        logger.Fatal().Err(err).Msg("failed to load guild configuration")
+    }
+
+    rcloneSync := cloud.NewRcloneSync(shutdownCtx, db, cfg.RcloneDrivePath, filepath.Join(cfg.LogDirectory, "sync"))
+
     // Initialize Discord bot
-    bot, err := discord.New(cfg.DiscordToken)
+    bot, err := discord.New(cfg.DiscordToken, guilds)
+    if err != nil {
+        logger.Fatal().Err(err).Msg("failed to initialize Discord bot")
+    }
+
+    // Build every compiled-in module, then load any plugin modules dropped
+    // into pluginDir.
+    deps := modules.Deps{
+        DB:      db,
+        Bot:     bot,
+        Config:  cfg,
+        Guilds:  guilds,
+        Scraper: modules.NewScraperContext(cfg),
+    }
+    modList := modules.Build(deps)
+    pluginModules, err := modules.LoadPlugins(pluginDir, deps)
     if err != nil {
-        log.Fatalf("Failed to initialize Discord bot: %v", err)
+        logger.Error().Err(err).Msg("failed to load plugin modules")
     }
+    modList = append(modList, pluginModules...)
 
-    // Initialize modules
-    infopulseModule := infopulse.New(db, bot, cfg)
-    specterModule := specter.New(db, bot, cfg)
-    validatorModule := validator.New(db, bot, cfg)
+    for _, mod := range modList {
+        mod.RegisterCommands(bot)
+    }
+
+    runner := modules.NewRunner(db, modList)
+    runner.RegisterCommands(bot)
 
     // Start Discord bot
     if err = bot.Start(); err != nil {
-        log.Fatalf("Failed to start Discord bot: %v", err)
+        logger.Fatal().Err(err).Msg("failed to start Discord bot")
     }
     defer bot.Stop()
 
-    // Run initial module tasks
-    if err := infopulseModule.Execute(); err != nil {
-        log.Printf("Error running infopulse module: %v", err)
-    }
-    if err := specterModule.Execute(); err != nil {
-        log.Printf("Error running specter module: %v", err)
-    }
-    if err := validatorModule.Execute(); err != nil {
-        log.Printf("Error running validator module: %v", err)
+    // Run every module once at boot, then let the Runner take over on each
+    // module's own schedule.
+    runner.RunAllOnce(shutdownCtx)
+    if err := runner.Start(); err != nil {
+        logger.Fatal().Err(err).Msg("failed to start module scheduler")
     }
 
     fmt.Println("Bot is now running. Press CTRL+C to exit.")
@@ -70,4 +147,12 @@ func main() {
     <-sc
 
     fmt.Println("Shutting down...")
+
+    <-runner.Stop().Done()
+
+    // Cancelling shutdownCtx sends SIGTERM to any in-flight rclone process
+    // (escalating to SIGKILL if it ignores that); Wait blocks until it has
+    // actually exited so we don't tear the process down mid-sync.
+    cancelShutdown()
+    rcloneSync.Wait()
 }
@@ -1,58 +1,196 @@
 package cloud
 
 import (
+    "bufio"
+    "context"
+    "encoding/json"
     "fmt"
+    "io"
     "os"
     "os/exec"
     "path/filepath"
+    "sync"
+    "syscall"
     "time"
+
+    "rinkokonoe/internal/database"
+    "rinkokonoe/internal/discord"
+    "rinkokonoe/internal/logging"
 )
 
+// reportDebounce is how long SaveReport waits for more reports to arrive
+// before actually kicking off a sync, so a burst of rapid reports coalesces
+// into one rclone run instead of one per report.
+const reportDebounce = 5 * time.Second
+
+// rcloneKillGrace is how long rclone gets to exit after SIGTERM before it's
+// sent SIGKILL.
+const rcloneKillGrace = 10 * time.Second
+
+// Progress is a snapshot of an in-progress rclone sync, parsed from its
+// --use-json-log stats output.
+type Progress struct {
+    BytesTransferred int64
+    FilesTransferred int64
+    FilesChecked     int64
+    Errors           int64
+    ETASeconds       float64
+    CurrentFile      string
+}
+
+// ProgressReporter is notified as a sync progresses.
+type ProgressReporter interface {
+    Report(p Progress)
+}
+
+// SyncResult summarizes a completed sync.
+type SyncResult struct {
+    Transferred int64
+    Failed      int64
+    Checked     int64
+    Bytes       int64
+    StartedAt   time.Time
+    FinishedAt  time.Time
+}
+
 // RcloneSync handles syncing data to Google Drive using rclone
 type RcloneSync struct {
+    db          *database.DB
     drivePath   string
     localPath   string
-    logFile     string
     concurrency int
+
+    // rootCtx is the parent for debounced syncs kicked off by SaveReport,
+    // so they're cancelled the same way an explicit SyncToGDrive call would
+    // be on shutdown.
+    rootCtx  context.Context
+    inFlight sync.WaitGroup
+
+    syncMu        sync.Mutex
+    syncScheduled bool
 }
 
-// NewRcloneSync creates a new RcloneSync instance
-func NewRcloneSync(drivePath, localPath, logDir string) *RcloneSync {
+// NewRcloneSync creates a new RcloneSync instance. rootCtx is used as the
+// parent context for syncs triggered internally by SaveReport; cancelling
+// it (e.g. on shutdown) cancels any such sync in progress.
+func NewRcloneSync(rootCtx context.Context, db *database.DB, drivePath, localPath string) *RcloneSync {
     return &RcloneSync{
+        db:          db,
         drivePath:   drivePath,
         localPath:   localPath,
-        logFile:     filepath.Join(logDir, "rclone.log"),
         concurrency: 2,
+        rootCtx:     rootCtx,
     }
 }
 
-// SyncToGDrive syncs local files to Google Drive
-func (r *RcloneSync) SyncToGDrive() error {
-    // Ensure local directory exists
+// Wait blocks until every in-flight sync started by this RcloneSync (either
+// via SyncToGDrive directly or a debounced SaveReport) has exited. Call
+// this during shutdown, after cancelling rootCtx, to let rclone finish
+// tearing down cleanly before the process returns.
+func (r *RcloneSync) Wait() {
+    r.inFlight.Wait()
+}
+
+// SyncToGDrive syncs local files to Google Drive. It streams rclone's JSON
+// stats to reporter as the sync progresses, and honors ctx cancellation by
+// sending SIGTERM to the rclone process (escalating to SIGKILL if it
+// doesn't exit within rcloneKillGrace).
+func (r *RcloneSync) SyncToGDrive(ctx context.Context, reporter ProgressReporter) (SyncResult, error) {
+    r.inFlight.Add(1)
+    defer r.inFlight.Done()
+
+    result := SyncResult{StartedAt: time.Now()}
+
     if err := os.MkdirAll(r.localPath, 0755); err != nil {
-        return fmt.Errorf("failed to create local directory: %w", err)
+        return result, fmt.Errorf("failed to create local directory: %w", err)
     }
 
-    // Run rclone sync command
-    cmd := exec.Command(
-        "rclone",
+    cmd := exec.CommandContext(ctx, "rclone",
         "sync",
         r.localPath,
         r.drivePath,
         "--transfers", fmt.Sprintf("%d", r.concurrency),
-        "-v",
-        "--log-file", r.logFile,
+        "--use-json-log",
+        "--stats", "1s",
     )
+    cmd.Cancel = func() error {
+        return cmd.Process.Signal(syscall.SIGTERM)
+    }
+    cmd.WaitDelay = rcloneKillGrace
 
-    output, err := cmd.CombinedOutput()
+    // Without --log-file, rclone's log/stats output (what --use-json-log
+    // formats) goes to its default destination: stderr.
+    stderr, err := cmd.StderrPipe()
     if err != nil {
-        return fmt.Errorf("rclone sync failed: %w\nOutput: %s", err, string(output))
+        return result, fmt.Errorf("failed to attach to rclone stderr: %w", err)
     }
 
-    return nil
+    if err := cmd.Start(); err != nil {
+        return result, fmt.Errorf("failed to start rclone: %w", err)
+    }
+
+    r.streamProgress(stderr, reporter, &result)
+
+    runErr := cmd.Wait()
+    result.FinishedAt = time.Now()
+
+    if saveErr := r.saveRun(result, runErr); saveErr != nil {
+        lg := logging.With("module", "cloud")
+        lg.Error().Err(saveErr).Msg("failed to save sync_runs row")
+    }
+
+    if runErr != nil {
+        return result, fmt.Errorf("rclone sync failed: %w", runErr)
+    }
+    return result, nil
+}
+
+// streamProgress reads rclone's --use-json-log output line by line,
+// reporting the latest stats to reporter and accumulating result's totals.
+func (r *RcloneSync) streamProgress(output io.Reader, reporter ProgressReporter, result *SyncResult) {
+    scanner := bufio.NewScanner(output)
+    for scanner.Scan() {
+        var line rcloneLogLine
+        if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+            continue // not every line is a stats line (or valid JSON)
+        }
+        if line.Stats == nil {
+            continue
+        }
+
+        result.Transferred = line.Stats.Transfers
+        result.Failed = line.Stats.Errors
+        result.Checked = line.Stats.Checks
+        result.Bytes = line.Stats.Bytes
+
+        if reporter != nil {
+            reporter.Report(Progress{
+                BytesTransferred: line.Stats.Bytes,
+                FilesTransferred: line.Stats.Transfers,
+                FilesChecked:     line.Stats.Checks,
+                Errors:           line.Stats.Errors,
+                ETASeconds:       line.Stats.ETA,
+                CurrentFile:      line.Object,
+            })
+        }
+    }
+}
+
+func (r *RcloneSync) saveRun(result SyncResult, runErr error) error {
+    errText := ""
+    if runErr != nil {
+        errText = runErr.Error()
+    }
+    _, err := r.db.GetDB().Exec(`
+        INSERT INTO sync_runs (started_at, finished_at, transferred, failed, checked, bytes, error)
+        VALUES (?, ?, ?, ?, ?, ?, ?)
+    `, result.StartedAt, result.FinishedAt, result.Transferred, result.Failed, result.Checked, result.Bytes, errText)
+    return err
 }
 
-// SaveReport saves a report to local storage and syncs to GDrive
+// SaveReport saves a report to local storage, then schedules a sync to
+// GDrive. Calls made within reportDebounce of the first report not yet
+// synced share a single scheduled sync instead of each triggering their own.
 func (r *RcloneSync) SaveReport(report []byte, reportType string) error {
     // Create filename with timestamp
     timestamp := time.Now().Format("2006-01-02_15-04-05")
@@ -70,6 +208,106 @@ func (r *RcloneSync) SaveReport(report []byte, reportType string) error {
         return fmt.Errorf("failed to write report: %w", err)
     }
 
-    // Sync to GDrive
-    return r.SyncToGDrive()
+    r.scheduleSync()
+    return nil
+}
+
+// scheduleSync arranges for a single sync to run reportDebounce from now,
+// coalescing any calls made before then.
+func (r *RcloneSync) scheduleSync() {
+    r.syncMu.Lock()
+    defer r.syncMu.Unlock()
+
+    if r.syncScheduled {
+        return
+    }
+    r.syncScheduled = true
+
+    time.AfterFunc(reportDebounce, func() {
+        r.syncMu.Lock()
+        r.syncScheduled = false
+        r.syncMu.Unlock()
+
+        ctx, cancel := context.WithTimeout(r.rootCtx, 15*time.Minute)
+        defer cancel()
+        if _, err := r.SyncToGDrive(ctx, StderrReporter{}); err != nil {
+            lg := logging.With("module", "cloud")
+            lg.Error().Err(err).Msg("debounced sync to GDrive failed")
+        }
+    })
+}
+
+// rcloneLogLine is one line of rclone's --use-json-log output.
+type rcloneLogLine struct {
+    Level  string       `json:"level"`
+    Msg    string       `json:"msg"`
+    Object string       `json:"object"`
+    Stats  *rcloneStats `json:"stats"`
+}
+
+// rcloneStats is the "stats" field rclone emits every --stats interval.
+type rcloneStats struct {
+    Bytes     int64   `json:"bytes"`
+    Checks    int64   `json:"checks"`
+    Errors    int64   `json:"errors"`
+    Transfers int64   `json:"transfers"`
+    ETA       float64 `json:"eta"`
+    Speed     float64 `json:"speed"`
+}
+
+// StderrReporter writes progress to stderr.
+type StderrReporter struct{}
+
+// Report implements ProgressReporter.
+func (StderrReporter) Report(p Progress) {
+    fmt.Fprintf(os.Stderr, "rclone sync: %d files, %d bytes, %d errors, eta %.0fs (%s)\n",
+        p.FilesTransferred, p.BytesTransferred, p.Errors, p.ETASeconds, p.CurrentFile)
+}
+
+// DiscordReporter edits a status message in channelID every interval with
+// the latest progress, instead of spamming a new message per update.
+type DiscordReporter struct {
+    bot       *discord.Bot
+    channelID string
+    interval  time.Duration
+
+    mu        sync.Mutex
+    messageID string
+    lastSent  time.Time
+}
+
+// NewDiscordReporter creates a reporter that posts (and then edits) a
+// status message in channelID, at most once per interval.
+func NewDiscordReporter(bot *discord.Bot, channelID string, interval time.Duration) *DiscordReporter {
+    return &DiscordReporter{bot: bot, channelID: channelID, interval: interval}
+}
+
+// Report implements ProgressReporter.
+func (d *DiscordReporter) Report(p Progress) {
+    d.mu.Lock()
+    defer d.mu.Unlock()
+
+    if !d.lastSent.IsZero() && time.Since(d.lastSent) < d.interval {
+        return
+    }
+    d.lastSent = time.Now()
+
+    content := fmt.Sprintf("Syncing to Google Drive: %d files transferred, %d bytes, %d errors, eta %.0fs",
+        p.FilesTransferred, p.BytesTransferred, p.Errors, p.ETASeconds)
+
+    if d.messageID == "" {
+        msg, err := d.bot.SendMessage(d.channelID, content)
+        if err != nil {
+            lg := logging.With("module", "cloud")
+            lg.Error().Err(err).Msg("failed to post sync status message")
+            return
+        }
+        d.messageID = msg.ID
+        return
+    }
+
+    if _, err := d.bot.EditMessage(d.channelID, d.messageID, content); err != nil {
+        lg := logging.With("module", "cloud")
+        lg.Error().Err(err).Msg("failed to edit sync status message")
+    }
 }
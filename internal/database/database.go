@@ -1,6 +1,7 @@
 package database
 
 import (
+    "context"
     "database/sql"
     "fmt"
     "os"
@@ -14,7 +15,8 @@ type DB struct {
     conn *sql.DB
 }
 
-// New creates a new database connection
+// New creates a new database connection. It does not apply schema
+// migrations; call MigrateUp once the caller is ready to do so.
 func New(dbPath string) (*DB, error) {
     // Ensure directory exists
     if err := ensureDir(filepath.Dir(dbPath)); err != nil {
@@ -32,15 +34,7 @@ func New(dbPath string) (*DB, error) {
         return nil, fmt.Errorf("failed to ping database: %w", err)
     }
 
-    db := &DB{conn: conn}
-
-    // Initialize schema
-    if err = db.initSchema(); err != nil {
-        conn.Close()
-        return nil, fmt.Errorf("failed to initialize schema: %w", err)
-    }
-
-    return db, nil
+    return &DB{conn: conn}, nil
 }
 
 // Close closes the database connection
@@ -58,40 +52,30 @@ func ensureDir(path string) error {
     return os.MkdirAll(path, 0755)
 }
 
-// initSchema creates the necessary tables
-func (db *DB) initSchema() error {
-    schemas := []string{
-        `CREATE TABLE IF NOT EXISTS tracked_products (
-            id INTEGER PRIMARY KEY AUTOINCREMENT,
-            name TEXT NOT NULL,
-            url TEXT NOT NULL UNIQUE,
-            current_price REAL,
-            original_price REAL,
-            lowest_price REAL,
-            last_updated TIMESTAMP
-        )`,
-        `CREATE TABLE IF NOT EXISTS price_history (
-            id INTEGER PRIMARY KEY AUTOINCREMENT,
-            product_id INTEGER,
-            price REAL,
-            recorded_at TIMESTAMP,
-            FOREIGN KEY (product_id) REFERENCES tracked_products(id)
-        )`,
-        `CREATE TABLE IF NOT EXISTS validations (
-            id INTEGER PRIMARY KEY AUTOINCREMENT,
-            entity_type TEXT NOT NULL,
-            entity_id TEXT NOT NULL,
-            status TEXT NOT NULL,
-            message TEXT,
-            checked_at TIMESTAMP
-        )`,
+// MigrateUp applies every pending migration, in order.
+func (db *DB) MigrateUp(ctx context.Context) error {
+    m, err := newMigrator(db.conn)
+    if err != nil {
+        return fmt.Errorf("failed to load migrations: %w", err)
     }
+    return m.MigrateUp(ctx)
+}
 
-    for _, schema := range schemas {
-        if _, err := db.conn.Exec(schema); err != nil {
-            return fmt.Errorf("failed to create schema: %w", err)
-        }
+// MigrateTo brings the schema to exactly the given version, applying or
+// rolling back migrations as needed.
+func (db *DB) MigrateTo(ctx context.Context, version int) error {
+    m, err := newMigrator(db.conn)
+    if err != nil {
+        return fmt.Errorf("failed to load migrations: %w", err)
     }
+    return m.MigrateTo(ctx, version)
+}
 
-    return nil
+// MigrationStatus returns the applied and pending migrations.
+func (db *DB) MigrationStatus(ctx context.Context) (applied, pending []Migration, err error) {
+    m, err := newMigrator(db.conn)
+    if err != nil {
+        return nil, nil, fmt.Errorf("failed to load migrations: %w", err)
+    }
+    return m.Status(ctx)
 }
@@ -0,0 +1,303 @@
+package database
+
+import (
+    "context"
+    "database/sql"
+    "embed"
+    "fmt"
+    "io/fs"
+    "regexp"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+)
+
+//go:embed migrations/*.sql
+var migrationFS embed.FS
+
+var migrationFileRE = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Migration is a single reversible schema (or data) change.
+type Migration struct {
+    Version int
+    Name    string
+    Up      func(tx *sql.Tx) error
+    Down    func(tx *sql.Tx) error
+}
+
+// Migrator applies Migrations to a database in ascending version order,
+// tracking progress in a schema_migrations table.
+type Migrator struct {
+    conn       *sql.DB
+    migrations []Migration
+}
+
+// newMigrator builds a Migrator from the embedded .sql files plus any
+// hand-written Go migrations (data migrations that need more than raw SQL).
+func newMigrator(conn *sql.DB) (*Migrator, error) {
+    migrations, err := loadSQLMigrations(migrationFS)
+    if err != nil {
+        return nil, err
+    }
+    migrations = append(migrations, normalizeLegacyDataMigration())
+
+    sort.Slice(migrations, func(i, j int) bool {
+        return migrations[i].Version < migrations[j].Version
+    })
+
+    return &Migrator{conn: conn, migrations: migrations}, nil
+}
+
+// loadSQLMigrations reads paired <version>_<name>.up.sql / .down.sql files
+// out of fsys and turns each pair into a Migration that execs the raw SQL.
+func loadSQLMigrations(fsys fs.FS) ([]Migration, error) {
+    entries, err := fs.ReadDir(fsys, "migrations")
+    if err != nil {
+        return nil, fmt.Errorf("failed to read migrations directory: %w", err)
+    }
+
+    type pair struct {
+        name   string
+        up     string
+        down   string
+        hasUp  bool
+        hasDown bool
+    }
+    byVersion := make(map[int]*pair)
+
+    for _, entry := range entries {
+        match := migrationFileRE.FindStringSubmatch(entry.Name())
+        if match == nil {
+            continue
+        }
+        version, err := strconv.Atoi(match[1])
+        if err != nil {
+            return nil, fmt.Errorf("invalid migration version in %s: %w", entry.Name(), err)
+        }
+
+        contents, err := fs.ReadFile(fsys, "migrations/"+entry.Name())
+        if err != nil {
+            return nil, fmt.Errorf("failed to read %s: %w", entry.Name(), err)
+        }
+
+        p, ok := byVersion[version]
+        if !ok {
+            p = &pair{name: match[2]}
+            byVersion[version] = p
+        }
+        switch match[3] {
+        case "up":
+            p.up, p.hasUp = string(contents), true
+        case "down":
+            p.down, p.hasDown = string(contents), true
+        }
+    }
+
+    migrations := make([]Migration, 0, len(byVersion))
+    for version, p := range byVersion {
+        if !p.hasUp {
+            return nil, fmt.Errorf("migration %d_%s is missing an .up.sql file", version, p.name)
+        }
+        upSQL, downSQL := p.up, p.down
+        migrations = append(migrations, Migration{
+            Version: version,
+            Name:    p.name,
+            Up: func(tx *sql.Tx) error {
+                _, err := tx.Exec(upSQL)
+                return err
+            },
+            Down: func(tx *sql.Tx) error {
+                if downSQL == "" {
+                    return fmt.Errorf("no .down.sql file provided for this migration")
+                }
+                _, err := tx.Exec(downSQL)
+                return err
+            },
+        })
+    }
+    return migrations, nil
+}
+
+// normalizeLegacyDataMigration backfills rows that predate the
+// schema_migrations table (i.e. databases created before this migrator
+// existed) so NULL columns left by the old ad hoc initSchema match what the
+// rest of the codebase now expects.
+func normalizeLegacyDataMigration() Migration {
+    return Migration{
+        Version: 3,
+        Name:    "normalize_legacy_data",
+        Up: func(tx *sql.Tx) error {
+            if _, err := tx.Exec(`UPDATE tracked_products SET lowest_price = current_price WHERE lowest_price IS NULL`); err != nil {
+                return fmt.Errorf("failed to normalize tracked_products.lowest_price: %w", err)
+            }
+            if _, err := tx.Exec(`UPDATE validations SET message = '' WHERE message IS NULL`); err != nil {
+                return fmt.Errorf("failed to normalize validations.message: %w", err)
+            }
+            return nil
+        },
+        Down: func(tx *sql.Tx) error {
+            // Backfilling NULLs isn't reversible; nothing to undo.
+            return nil
+        },
+    }
+}
+
+func (m *Migrator) ensureVersionTable(ctx context.Context) error {
+    _, err := m.conn.ExecContext(ctx, `
+        CREATE TABLE IF NOT EXISTS schema_migrations (
+            version INTEGER PRIMARY KEY,
+            applied_at TIMESTAMP NOT NULL
+        )
+    `)
+    if err != nil {
+        return fmt.Errorf("failed to create schema_migrations table: %w", err)
+    }
+    return nil
+}
+
+func (m *Migrator) appliedVersions(ctx context.Context) (map[int]bool, error) {
+    rows, err := m.conn.QueryContext(ctx, `SELECT version FROM schema_migrations`)
+    if err != nil {
+        return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+    }
+    defer rows.Close()
+
+    applied := make(map[int]bool)
+    for rows.Next() {
+        var v int
+        if err := rows.Scan(&v); err != nil {
+            return nil, err
+        }
+        applied[v] = true
+    }
+    return applied, rows.Err()
+}
+
+// checkForDowngrade refuses to run if a recorded version isn't one this
+// binary knows about — that means a newer binary migrated this database and
+// running an older binary against it would silently skip schema it doesn't
+// understand.
+func (m *Migrator) checkForDowngrade(applied map[int]bool) error {
+    known := make(map[int]bool, len(m.migrations))
+    for _, mig := range m.migrations {
+        known[mig.Version] = true
+    }
+    for v := range applied {
+        if !known[v] {
+            return fmt.Errorf("database has migration version %d applied, which this binary does not recognize (refusing to start; this looks like a downgrade)", v)
+        }
+    }
+    return nil
+}
+
+// MigrateUp applies every migration newer than the database's current
+// version, in order, each inside its own transaction.
+func (m *Migrator) MigrateUp(ctx context.Context) error {
+    if err := m.ensureVersionTable(ctx); err != nil {
+        return err
+    }
+    applied, err := m.appliedVersions(ctx)
+    if err != nil {
+        return err
+    }
+    if err := m.checkForDowngrade(applied); err != nil {
+        return err
+    }
+
+    for _, mig := range m.migrations {
+        if applied[mig.Version] {
+            continue
+        }
+        if err := m.apply(ctx, mig); err != nil {
+            return fmt.Errorf("migration %d_%s failed: %w", mig.Version, mig.Name, err)
+        }
+    }
+    return nil
+}
+
+// MigrateTo brings the database to exactly targetVersion, running ups or
+// downs as needed.
+func (m *Migrator) MigrateTo(ctx context.Context, targetVersion int) error {
+    if err := m.ensureVersionTable(ctx); err != nil {
+        return err
+    }
+    applied, err := m.appliedVersions(ctx)
+    if err != nil {
+        return err
+    }
+    if err := m.checkForDowngrade(applied); err != nil {
+        return err
+    }
+
+    for _, mig := range m.migrations {
+        switch {
+        case mig.Version <= targetVersion && !applied[mig.Version]:
+            if err := m.apply(ctx, mig); err != nil {
+                return fmt.Errorf("migration %d_%s failed: %w", mig.Version, mig.Name, err)
+            }
+        case mig.Version > targetVersion && applied[mig.Version]:
+            if err := m.revert(ctx, mig); err != nil {
+                return fmt.Errorf("rollback of %d_%s failed: %w", mig.Version, mig.Name, err)
+            }
+        }
+    }
+    return nil
+}
+
+func (m *Migrator) apply(ctx context.Context, mig Migration) error {
+    tx, err := m.conn.BeginTx(ctx, nil)
+    if err != nil {
+        return err
+    }
+    if err := mig.Up(tx); err != nil {
+        tx.Rollback()
+        return err
+    }
+    if _, err := tx.Exec(`INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`, mig.Version, time.Now()); err != nil {
+        tx.Rollback()
+        return err
+    }
+    return tx.Commit()
+}
+
+func (m *Migrator) revert(ctx context.Context, mig Migration) error {
+    tx, err := m.conn.BeginTx(ctx, nil)
+    if err != nil {
+        return err
+    }
+    if err := mig.Down(tx); err != nil {
+        tx.Rollback()
+        return err
+    }
+    if _, err := tx.Exec(`DELETE FROM schema_migrations WHERE version = ?`, mig.Version); err != nil {
+        tx.Rollback()
+        return err
+    }
+    return tx.Commit()
+}
+
+// Status reports the migrations already applied and those still pending.
+func (m *Migrator) Status(ctx context.Context) (applied []Migration, pending []Migration, err error) {
+    if err := m.ensureVersionTable(ctx); err != nil {
+        return nil, nil, err
+    }
+    appliedVersions, err := m.appliedVersions(ctx)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    for _, mig := range m.migrations {
+        if appliedVersions[mig.Version] {
+            applied = append(applied, mig)
+        } else {
+            pending = append(pending, mig)
+        }
+    }
+    return applied, pending, nil
+}
+
+// String renders "0001 - initial_schema" style for CLI status output.
+func (mig Migration) String() string {
+    return fmt.Sprintf("%04d - %s", mig.Version, strings.ReplaceAll(mig.Name, " ", "_"))
+}
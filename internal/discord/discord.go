@@ -2,24 +2,30 @@ package discord
 
 import (
     "fmt"
-    "log"
     "strings"
 
     "github.com/bwmarrin/discordgo"
+
+    "rinkokonoe/internal/guild"
+    "rinkokonoe/internal/logging"
 )
 
 // Bot represents the Discord bot
 type Bot struct {
     session      *discordgo.Session
+    guilds       *guild.Store
     commands     map[string]CommandHandler
     commandDescs map[string]string
+    commandMods  map[string]string // command name -> owning module, empty for core commands
 }
 
-// CommandHandler is a function that handles a Discord command
-type CommandHandler func(s *discordgo.Session, m *discordgo.MessageCreate, args []string) error
+// CommandHandler is a function that handles a Discord command. g is the
+// calling guild's configuration, looked up (and lazily created) before the
+// handler runs.
+type CommandHandler func(s *discordgo.Session, m *discordgo.MessageCreate, g *guild.Guild, args []string) error
 
 // New creates a new Discord bot
-func New(token string) (*Bot, error) {
+func New(token string, guilds *guild.Store) (*Bot, error) {
     session, err := discordgo.New("Bot " + token)
     if err != nil {
         return nil, fmt.Errorf("failed to create Discord session: %w", err)
@@ -27,16 +33,22 @@ func New(token string) (*Bot, error) {
 
     bot := &Bot{
         session:      session,
+        guilds:       guilds,
         commands:     make(map[string]CommandHandler),
         commandDescs: make(map[string]string),
+        commandMods:  make(map[string]string),
     }
 
-    // Register message handler
+    // Register message and guild lifecycle handlers
     session.AddHandler(bot.messageCreate)
+    session.AddHandler(bot.guildCreate)
+    session.AddHandler(bot.guildDelete)
 
     // Register basic commands
     bot.RegisterCommand("help", "Display available commands", bot.helpCommand)
     bot.RegisterCommand("ping", "Check if bot is alive", bot.pingCommand)
+    bot.RegisterCommand("setup", "Configure prefix, announcement channel, and modules (admin only)", bot.setupCommand)
+    bot.RegisterCommand("loglevel", "Adjust the log level (admin only)", bot.loglevelCommand)
 
     return bot, nil
 }
@@ -51,18 +63,47 @@ func (b *Bot) Stop() error {
     return b.session.Close()
 }
 
-// RegisterCommand registers a new command
+// RegisterCommand registers a core command, available in every guild
+// regardless of enabled modules.
 func (b *Bot) RegisterCommand(name, description string, handler CommandHandler) {
     name = strings.ToLower(name)
     b.commands[name] = handler
     b.commandDescs[name] = description
 }
 
+// RegisterModuleCommand registers a command owned by moduleName. It is only
+// dispatched in guilds where that module is enabled.
+func (b *Bot) RegisterModuleCommand(moduleName, name, description string, handler CommandHandler) {
+    name = strings.ToLower(name)
+    b.commands[name] = handler
+    b.commandDescs[name] = description
+    b.commandMods[name] = moduleName
+}
+
 // SendMessage sends a message to a channel
 func (b *Bot) SendMessage(channelID, content string) (*discordgo.Message, error) {
     return b.session.ChannelMessageSend(channelID, content)
 }
 
+// EditMessage edits a previously sent message.
+func (b *Bot) EditMessage(channelID, messageID, content string) (*discordgo.Message, error) {
+    return b.session.ChannelMessageEdit(channelID, messageID, content)
+}
+
+// guildCreate caches a Guild record when the bot joins a server, or on the
+// startup backfill Discord sends for every guild it's already in.
+func (b *Bot) guildCreate(s *discordgo.Session, ev *discordgo.GuildCreate) {
+    if _, err := b.guilds.GetOrCreate(ev.ID); err != nil {
+        lg := logging.With("module", "discord", "guild_id", ev.ID)
+        lg.Error().Err(err).Msg("failed to load guild")
+    }
+}
+
+// guildDelete drops the cached Guild when the bot is removed from a server.
+func (b *Bot) guildDelete(s *discordgo.Session, ev *discordgo.GuildDelete) {
+    b.guilds.Remove(ev.ID)
+}
+
 // messageCreate handles incoming messages
 func (b *Bot) messageCreate(s *discordgo.Session, m *discordgo.MessageCreate) {
     // Ignore messages from the bot itself
@@ -70,13 +111,25 @@ func (b *Bot) messageCreate(s *discordgo.Session, m *discordgo.MessageCreate) {
         return
     }
 
-    // Check if message starts with "!" for commands
-    if !strings.HasPrefix(m.Content, "!") {
+    // Only route guild messages; DMs have no per-guild config to route with
+    if m.GuildID == "" {
+        return
+    }
+
+    g, err := b.guilds.GetOrCreate(m.GuildID)
+    if err != nil {
+        lg := logging.With("module", "discord", "guild_id", m.GuildID)
+        lg.Error().Err(err).Msg("failed to load guild")
+        return
+    }
+
+    // Check if message starts with the guild's configured prefix
+    if !strings.HasPrefix(m.Content, g.Prefix) {
         return
     }
 
     // Parse command and arguments
-    parts := strings.Fields(m.Content[1:])
+    parts := strings.Fields(m.Content[len(g.Prefix):])
     if len(parts) == 0 {
         return
     }
@@ -84,22 +137,60 @@ func (b *Bot) messageCreate(s *discordgo.Session, m *discordgo.MessageCreate) {
     cmd := strings.ToLower(parts[0])
     args := parts[1:]
 
-    // Execute command if registered
-    if handler, ok := b.commands[cmd]; ok {
-        if err := handler(s, m, args); err != nil {
-            log.Printf("Error executing command %s: %v", cmd, err)
-            s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: %v", err))
+    handler, ok := b.commands[cmd]
+    if !ok {
+        return
+    }
+
+    // Module-owned commands only run in guilds where that module is enabled
+    if mod, isModuleCmd := b.commandMods[cmd]; isModuleCmd && !g.ModuleEnabled(mod) {
+        return
+    }
+
+    cmdLogger := logging.With(
+        "module", "discord",
+        "guild_id", m.GuildID,
+        "channel_id", m.ChannelID,
+        "user_id", m.Author.ID,
+        "command", cmd,
+    )
+
+    if err := handler(s, m, g, args); err != nil {
+        cmdLogger.Error().Err(err).Msg("command failed")
+        s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: %v", err))
+    }
+}
+
+// isAdmin reports whether the message author may run admin-gated commands:
+// Discord server administrators, or members holding one of the guild's
+// configured ACL roles.
+func (b *Bot) isAdmin(s *discordgo.Session, m *discordgo.MessageCreate, g *guild.Guild) bool {
+    member, err := s.GuildMember(m.GuildID, m.Author.ID)
+    if err != nil {
+        return false
+    }
+    for _, roleID := range member.Roles {
+        if g.HasRole(roleID) {
+            return true
+        }
+        role, err := s.State.Role(m.GuildID, roleID)
+        if err == nil && role.Permissions&discordgo.PermissionAdministrator != 0 {
+            return true
         }
     }
+    return false
 }
 
 // helpCommand handles the help command
-func (b *Bot) helpCommand(s *discordgo.Session, m *discordgo.MessageCreate, args []string) error {
+func (b *Bot) helpCommand(s *discordgo.Session, m *discordgo.MessageCreate, g *guild.Guild, args []string) error {
     var sb strings.Builder
     sb.WriteString("**Available Commands:**\n")
 
     for cmd, desc := range b.commandDescs {
-        sb.WriteString(fmt.Sprintf("â€¢ `!%s` - %s\n", cmd, desc))
+        if mod, isModuleCmd := b.commandMods[cmd]; isModuleCmd && !g.ModuleEnabled(mod) {
+            continue
+        }
+        sb.WriteString(fmt.Sprintf("â€¢ `%s%s` - %s\n", g.Prefix, cmd, desc))
     }
 
     _, err := s.ChannelMessageSend(m.ChannelID, sb.String())
@@ -107,7 +198,83 @@ func (b *Bot) helpCommand(s *discordgo.Session, m *discordgo.MessageCreate, args
 }
 
 // pingCommand handles the ping command
-func (b *Bot) pingCommand(s *discordgo.Session, m *discordgo.MessageCreate, args []string) error {
+func (b *Bot) pingCommand(s *discordgo.Session, m *discordgo.MessageCreate, g *guild.Guild, args []string) error {
     _, err := s.ChannelMessageSend(m.ChannelID, "Pong!")
     return err
 }
+
+// parseChannelID extracts the channel ID from a Discord channel mention
+// (<#123456789>), or returns s unchanged if it isn't one, so a raw ID
+// typed by hand still works.
+func parseChannelID(s string) string {
+    if strings.HasPrefix(s, "<#") && strings.HasSuffix(s, ">") {
+        return strings.TrimSuffix(strings.TrimPrefix(s, "<#"), ">")
+    }
+    return s
+}
+
+// setupCommand lets a server admin configure the guild's prefix,
+// announcement channel, and enabled modules.
+//
+// Usage:
+//
+//	!setup prefix <prefix>
+//	!setup channel <#channel>
+//	!setup module <name> <on|off>
+func (b *Bot) setupCommand(s *discordgo.Session, m *discordgo.MessageCreate, g *guild.Guild, args []string) error {
+    if !b.isAdmin(s, m, g) {
+        return fmt.Errorf("this command is restricted to server admins")
+    }
+    if len(args) < 2 {
+        return fmt.Errorf("usage: %ssetup <prefix|channel|module> <value>", g.Prefix)
+    }
+
+    var err error
+    switch strings.ToLower(args[0]) {
+    case "prefix":
+        err = b.guilds.SetPrefix(g.ID, args[1])
+    case "channel":
+        err = b.guilds.SetAnnounceChannel(g.ID, parseChannelID(args[1]))
+    case "module":
+        if len(args) < 3 {
+            return fmt.Errorf("usage: %ssetup module <name> <on|off>", g.Prefix)
+        }
+        name := strings.ToLower(args[1])
+        switch strings.ToLower(args[2]) {
+        case "on", "enable":
+            err = b.guilds.SetModuleEnabled(g.ID, name, true)
+        case "off", "disable":
+            err = b.guilds.SetModuleEnabled(g.ID, name, false)
+        default:
+            return fmt.Errorf("usage: %ssetup module <name> <on|off>", g.Prefix)
+        }
+    default:
+        return fmt.Errorf("usage: %ssetup <prefix|channel|module> <value>", g.Prefix)
+    }
+    if err != nil {
+        return fmt.Errorf("failed to save guild settings: %w", err)
+    }
+
+    _, err = s.ChannelMessageSend(m.ChannelID, "Settings updated.")
+    return err
+}
+
+// loglevelCommand lets a server admin adjust the bot's log level at
+// runtime, e.g. `!loglevel debug`.
+func (b *Bot) loglevelCommand(s *discordgo.Session, m *discordgo.MessageCreate, g *guild.Guild, args []string) error {
+    if !b.isAdmin(s, m, g) {
+        return fmt.Errorf("this command is restricted to server admins")
+    }
+    if len(args) != 1 {
+        return fmt.Errorf("usage: %sloglevel <debug|info|warn|error>", g.Prefix)
+    }
+
+    level, err := logging.ParseLevel(args[0])
+    if err != nil {
+        return err
+    }
+    logging.SetLevel(level)
+
+    _, err = s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Log level set to %s.", level))
+    return err
+}
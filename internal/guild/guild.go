@@ -0,0 +1,261 @@
+package guild
+
+import (
+    "database/sql"
+    "fmt"
+    "strings"
+    "sync"
+    "time"
+)
+
+// Guild represents per-server configuration for the bot.
+type Guild struct {
+    ID              string
+    Prefix          string
+    Locale          string
+    AnnounceChannel string
+    EnabledModules  map[string]bool
+    ACLRoles        []string
+}
+
+// ModuleEnabled reports whether the named module is enabled for this guild.
+func (g *Guild) ModuleEnabled(name string) bool {
+    if g.EnabledModules == nil {
+        return false
+    }
+    return g.EnabledModules[name]
+}
+
+// HasRole reports whether roleID is one of the guild's configured ACL roles.
+func (g *Guild) HasRole(roleID string) bool {
+    for _, r := range g.ACLRoles {
+        if r == roleID {
+            return true
+        }
+    }
+    return false
+}
+
+// clone returns a deep copy of g, so callers can mutate the result without
+// affecting any Guild already published to the Store's cache.
+func (g *Guild) clone() *Guild {
+    modules := make(map[string]bool, len(g.EnabledModules))
+    for name, enabled := range g.EnabledModules {
+        modules[name] = enabled
+    }
+    c := *g
+    c.EnabledModules = modules
+    c.ACLRoles = append([]string(nil), g.ACLRoles...)
+    return &c
+}
+
+func defaultGuild(id string) *Guild {
+    return &Guild{
+        ID:             id,
+        Prefix:         "!",
+        Locale:         "en",
+        EnabledModules: map[string]bool{"infopulse": true, "specter": true, "validator": true},
+    }
+}
+
+// Store loads and caches Guild records backed by SQLite.
+type Store struct {
+    conn *sql.DB
+
+    mu     sync.RWMutex
+    guilds map[string]*Guild
+}
+
+// NewStore creates a Store backed by conn. The guilds table is expected to
+// already exist (created by the database migrations applied in
+// database.DB.MigrateUp).
+func NewStore(conn *sql.DB) *Store {
+    return &Store{
+        conn:   conn,
+        guilds: make(map[string]*Guild),
+    }
+}
+
+// Load reads every guild row into the in-memory cache. Call once at startup.
+func (s *Store) Load() error {
+    rows, err := s.conn.Query(`SELECT id, prefix, locale, announce_channel, enabled_modules, acl_roles FROM guilds`)
+    if err != nil {
+        return fmt.Errorf("failed to load guilds: %w", err)
+    }
+    defer rows.Close()
+
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    for rows.Next() {
+        var (
+            id, prefix, locale, enabledModules, aclRoles string
+            announceChannel                              sql.NullString
+        )
+        if err := rows.Scan(&id, &prefix, &locale, &announceChannel, &enabledModules, &aclRoles); err != nil {
+            return fmt.Errorf("failed to scan guild row: %w", err)
+        }
+        g := &Guild{
+            ID:              id,
+            Prefix:          prefix,
+            Locale:          locale,
+            AnnounceChannel: announceChannel.String,
+            EnabledModules:  decodeModules(enabledModules),
+            ACLRoles:        decodeList(aclRoles),
+        }
+        s.guilds[id] = g
+    }
+    return rows.Err()
+}
+
+// Get returns the cached Guild for id, if any.
+func (s *Store) Get(id string) (*Guild, bool) {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+    g, ok := s.guilds[id]
+    return g, ok
+}
+
+// GetOrCreate returns the cached Guild for id. On a cache miss it loads the
+// guild's row if one exists, and otherwise creates and persists a
+// default-configured one (called on GuildCreate when no row exists yet).
+func (s *Store) GetOrCreate(id string) (*Guild, error) {
+    if g, ok := s.Get(id); ok {
+        return g, nil
+    }
+    var (
+        prefix, locale, enabledModules, aclRoles string
+        announceChannel                          sql.NullString
+    )
+    err := s.conn.QueryRow(`SELECT prefix, locale, announce_channel, enabled_modules, acl_roles FROM guilds WHERE id = ?`, id).
+        Scan(&prefix, &locale, &announceChannel, &enabledModules, &aclRoles)
+    if err == nil {
+        g := &Guild{ID: id, Prefix: prefix, Locale: locale, AnnounceChannel: announceChannel.String,
+            EnabledModules: decodeModules(enabledModules), ACLRoles: decodeList(aclRoles)}
+        s.mu.Lock()
+        s.guilds[id] = g
+        s.mu.Unlock()
+        return g, nil
+    } else if err != sql.ErrNoRows {
+        return nil, fmt.Errorf("failed to load guild %s: %w", id, err)
+    }
+    g := defaultGuild(id)
+    if err := s.Upsert(g); err != nil {
+        return nil, err
+    }
+    return g, nil
+}
+
+// Upsert persists g and updates the cache.
+func (s *Store) Upsert(g *Guild) error {
+    _, err := s.conn.Exec(`
+        INSERT INTO guilds (id, prefix, locale, announce_channel, enabled_modules, acl_roles, updated_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?)
+        ON CONFLICT(id) DO UPDATE SET
+            prefix = excluded.prefix,
+            locale = excluded.locale,
+            announce_channel = excluded.announce_channel,
+            enabled_modules = excluded.enabled_modules,
+            acl_roles = excluded.acl_roles,
+            updated_at = excluded.updated_at
+    `, g.ID, g.Prefix, g.Locale, g.AnnounceChannel, encodeModules(g.EnabledModules), encodeList(g.ACLRoles), time.Now())
+    if err != nil {
+        return fmt.Errorf("failed to upsert guild %s: %w", g.ID, err)
+    }
+
+    s.mu.Lock()
+    s.guilds[g.ID] = g
+    s.mu.Unlock()
+    return nil
+}
+
+// SetPrefix updates id's command prefix.
+func (s *Store) SetPrefix(id, prefix string) error {
+    return s.update(id, func(g *Guild) { g.Prefix = prefix })
+}
+
+// SetAnnounceChannel updates id's announcement channel.
+func (s *Store) SetAnnounceChannel(id, channelID string) error {
+    return s.update(id, func(g *Guild) { g.AnnounceChannel = channelID })
+}
+
+// SetModuleEnabled enables or disables module for id.
+func (s *Store) SetModuleEnabled(id, module string, enabled bool) error {
+    return s.update(id, func(g *Guild) {
+        if enabled {
+            if g.EnabledModules == nil {
+                g.EnabledModules = make(map[string]bool)
+            }
+            g.EnabledModules[module] = true
+        } else {
+            delete(g.EnabledModules, module)
+        }
+    })
+}
+
+// update clones the cached Guild for id, applies mutate to the clone, and
+// persists+publishes the clone atomically. Mutating the cached *Guild
+// directly would race with goroutines reading it concurrently (e.g. the
+// module Runner calling Guild.ModuleEnabled from its own goroutine per
+// scheduled module); cloning before mutating means readers only ever see
+// a fully-formed Guild.
+func (s *Store) update(id string, mutate func(*Guild)) error {
+    s.mu.Lock()
+    g, ok := s.guilds[id]
+    s.mu.Unlock()
+    if !ok {
+        return fmt.Errorf("guild %s not found", id)
+    }
+
+    clone := g.clone()
+    mutate(clone)
+    return s.Upsert(clone)
+}
+
+// Remove drops id from the cache. Called on GuildDelete; the row is kept so
+// settings survive the bot being re-invited.
+func (s *Store) Remove(id string) {
+    s.mu.Lock()
+    delete(s.guilds, id)
+    s.mu.Unlock()
+}
+
+// All returns every cached guild.
+func (s *Store) All() []*Guild {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+    out := make([]*Guild, 0, len(s.guilds))
+    for _, g := range s.guilds {
+        out = append(out, g)
+    }
+    return out
+}
+
+func encodeModules(m map[string]bool) string {
+    names := make([]string, 0, len(m))
+    for name, enabled := range m {
+        if enabled {
+            names = append(names, name)
+        }
+    }
+    return strings.Join(names, ",")
+}
+
+func decodeModules(s string) map[string]bool {
+    out := make(map[string]bool)
+    for _, name := range decodeList(s) {
+        out[name] = true
+    }
+    return out
+}
+
+func encodeList(items []string) string {
+    return strings.Join(items, ",")
+}
+
+func decodeList(s string) []string {
+    if s == "" {
+        return nil
+    }
+    return strings.Split(s, ",")
+}
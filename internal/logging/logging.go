@@ -0,0 +1,120 @@
+// Package logging provides the bot's structured, leveled logger: JSON to
+// disk with daily rotation, optionally mirrored as human-readable output to
+// stderr, with a runtime-adjustable level.
+package logging
+
+import (
+    "fmt"
+    "io"
+    "os"
+    "path/filepath"
+    "strings"
+    "sync/atomic"
+    "time"
+
+    "github.com/rs/zerolog"
+    "gopkg.in/natefinch/lumberjack.v2"
+)
+
+var (
+    currentLevel atomic.Value
+    output       io.Writer = os.Stderr
+)
+
+func init() {
+    currentLevel.Store(zerolog.InfoLevel)
+}
+
+// Init wires the global logger: JSON lines to <logDir>/opsbot.log, rotated
+// daily via lumberjack, mirrored as human-readable output to stderr when
+// environment is "development". The level is read from the LOG_LEVEL
+// environment variable (debug/info/warn/error), defaulting to info. Call
+// once at startup before any other package logs.
+func Init(logDir, environment string) error {
+    if err := os.MkdirAll(logDir, 0755); err != nil {
+        return fmt.Errorf("failed to create log directory: %w", err)
+    }
+
+    level, err := ParseLevel(os.Getenv("LOG_LEVEL"))
+    if err != nil {
+        level = zerolog.InfoLevel
+    }
+    SetLevel(level)
+
+    fileWriter := &lumberjack.Logger{
+        Filename: filepath.Join(logDir, "opsbot.log"),
+        MaxAge:   1, // days to keep rotated files; rotateDaily does the rotating
+        Compress: true,
+    }
+
+    if environment == "development" {
+        output = zerolog.MultiLevelWriter(fileWriter, zerolog.ConsoleWriter{Out: os.Stderr, TimeFormat: "15:04:05"})
+    } else {
+        output = fileWriter
+    }
+
+    go rotateDaily(fileWriter)
+
+    return nil
+}
+
+// rotateDaily calls fileWriter.Rotate at the next midnight, and every
+// midnight after that. Lumberjack only rotates on size or an explicit
+// Rotate() call, so this is what actually makes "rotated daily" true.
+func rotateDaily(fileWriter *lumberjack.Logger) {
+    for {
+        now := time.Now()
+        next := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+        time.Sleep(time.Until(next))
+        if err := fileWriter.Rotate(); err != nil {
+            lg := With("module", "logging")
+            lg.Error().Err(err).Msg("failed to rotate log file")
+        }
+    }
+}
+
+// SetLevel changes the minimum level logged at runtime, e.g. from the
+// `!loglevel` Discord command.
+func SetLevel(level zerolog.Level) {
+    currentLevel.Store(level)
+}
+
+// ParseLevel parses a LOG_LEVEL-style string. An empty string parses as
+// info.
+func ParseLevel(s string) (zerolog.Level, error) {
+    switch strings.ToLower(strings.TrimSpace(s)) {
+    case "", "info":
+        return zerolog.InfoLevel, nil
+    case "debug":
+        return zerolog.DebugLevel, nil
+    case "warn", "warning":
+        return zerolog.WarnLevel, nil
+    case "error":
+        return zerolog.ErrorLevel, nil
+    default:
+        return zerolog.InfoLevel, fmt.Errorf("unknown log level %q", s)
+    }
+}
+
+func level() zerolog.Level {
+    if l, ok := currentLevel.Load().(zerolog.Level); ok {
+        return l
+    }
+    return zerolog.InfoLevel
+}
+
+// Logger returns the base logger at the current level.
+func Logger() zerolog.Logger {
+    return zerolog.New(output).Level(level()).With().Timestamp().Logger()
+}
+
+// With returns a child logger annotated with the given key/value pairs, e.g.
+// logging.With("module", "infopulse", "guild_id", guildID).
+func With(keyvals ...interface{}) zerolog.Logger {
+    ctx := Logger().With()
+    for i := 0; i+1 < len(keyvals); i += 2 {
+        key, _ := keyvals[i].(string)
+        ctx = ctx.Interface(key, keyvals[i+1])
+    }
+    return ctx.Logger()
+}
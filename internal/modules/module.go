@@ -0,0 +1,49 @@
+// Package modules defines the pluggable scraper/bot module subsystem:
+// a Module interface, a Registry that compiled-in modules register into via
+// init(), and a Runner that schedules each Module on its own cron Schedule.
+package modules
+
+import (
+    "context"
+
+    "rinkokonoe/internal/config"
+    "rinkokonoe/internal/database"
+    "rinkokonoe/internal/discord"
+    "rinkokonoe/internal/guild"
+)
+
+// Module is a scheduled unit of bot functionality (a scraper, a validator,
+// etc.) that the Runner fires on its own cron Schedule.
+type Module interface {
+    // Name uniquely identifies the module, e.g. "infopulse".
+    Name() string
+
+    // Schedule is a standard 5-field cron expression describing how often
+    // Execute should run.
+    Schedule() string
+
+    // Execute runs the module's task. It must respect ctx cancellation and
+    // its deadline; the Runner enforces a per-module timeout through ctx.
+    Execute(ctx context.Context) error
+
+    // RegisterCommands registers any Discord commands this module owns.
+    RegisterCommands(bot *discord.Bot)
+
+    // HealthCheck reports whether the module is in a usable state.
+    HealthCheck() error
+}
+
+// Deps are the shared dependencies handed to every module Factory,
+// compiled-in or loaded from a plugin.
+type Deps struct {
+    DB      *database.DB
+    Bot     *discord.Bot
+    Config  *config.Config
+    Guilds  *guild.Store
+    Scraper *ScraperContext
+}
+
+// Factory builds a Module from shared Deps. Compiled-in modules register a
+// Factory from their package init(); plugins export one under the symbol
+// name "NewModule".
+type Factory func(Deps) Module
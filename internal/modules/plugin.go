@@ -0,0 +1,59 @@
+//go:build linux || darwin
+
+package modules
+
+import (
+    "errors"
+    "fmt"
+    "os"
+    "path/filepath"
+    "plugin"
+    "strings"
+)
+
+// LoadPlugins opens every .so file in dir and instantiates the Module it
+// exports, so operators can drop in additional scrapers without rebuilding
+// the main binary. A missing dir is not an error — plugins are optional. A
+// plugin that fails to load is skipped rather than aborting the rest, with
+// its error joined into the returned error for visibility.
+//
+// Each plugin must export a symbol named "NewModule" of type
+// func(modules.Deps) modules.Module.
+func LoadPlugins(dir string, deps Deps) ([]Module, error) {
+    entries, err := os.ReadDir(dir)
+    if err != nil {
+        if os.IsNotExist(err) {
+            return nil, nil
+        }
+        return nil, fmt.Errorf("failed to read plugins directory: %w", err)
+    }
+
+    var mods []Module
+    var errs []error
+    for _, entry := range entries {
+        if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".so") {
+            continue
+        }
+
+        p, err := plugin.Open(filepath.Join(dir, entry.Name()))
+        if err != nil {
+            errs = append(errs, fmt.Errorf("failed to open plugin %s: %w", entry.Name(), err))
+            continue
+        }
+
+        sym, err := p.Lookup("NewModule")
+        if err != nil {
+            errs = append(errs, fmt.Errorf("plugin %s: missing NewModule symbol: %w", entry.Name(), err))
+            continue
+        }
+
+        factory, ok := sym.(func(Deps) Module)
+        if !ok {
+            errs = append(errs, fmt.Errorf("plugin %s: NewModule has the wrong signature", entry.Name()))
+            continue
+        }
+
+        mods = append(mods, factory(deps))
+    }
+    return mods, errors.Join(errs...)
+}
@@ -0,0 +1,11 @@
+//go:build !(linux || darwin)
+
+package modules
+
+import "fmt"
+
+// LoadPlugins is unavailable on this platform: Go's plugin package only
+// supports linux and darwin.
+func LoadPlugins(dir string, deps Deps) ([]Module, error) {
+    return nil, fmt.Errorf("dynamic module plugins are not supported on this platform")
+}
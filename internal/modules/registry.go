@@ -0,0 +1,36 @@
+package modules
+
+import "sync"
+
+var (
+    registryMu    sync.Mutex
+    registry      = map[string]Factory{}
+    registryOrder []string
+)
+
+// Register adds a compiled-in module Factory under name. Module packages
+// call this from their own init() so importing the package for its
+// side-effects is enough to make it available to Build.
+func Register(name string, factory Factory) {
+    registryMu.Lock()
+    defer registryMu.Unlock()
+    if _, exists := registry[name]; !exists {
+        registryOrder = append(registryOrder, name)
+    }
+    registry[name] = factory
+}
+
+// Build instantiates every compiled-in module registered so far with deps,
+// in registration order. Map iteration order is randomized per process, so
+// registryOrder is what lets RunAllOnce's "in registration order" guarantee
+// (and the !modules command's listing order) actually hold across restarts.
+func Build(deps Deps) []Module {
+    registryMu.Lock()
+    defer registryMu.Unlock()
+
+    mods := make([]Module, 0, len(registryOrder))
+    for _, name := range registryOrder {
+        mods = append(mods, registry[name](deps))
+    }
+    return mods
+}
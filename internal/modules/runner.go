@@ -0,0 +1,180 @@
+package modules
+
+import (
+    "context"
+    "fmt"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/bwmarrin/discordgo"
+    "github.com/robfig/cron/v3"
+
+    "rinkokonoe/internal/database"
+    "rinkokonoe/internal/discord"
+    "rinkokonoe/internal/guild"
+    "rinkokonoe/internal/logging"
+)
+
+// defaultTimeout bounds how long a single module Execute call may run
+// before the Runner cancels its context.
+const defaultTimeout = 5 * time.Minute
+
+// runRecord is the Runner's in-memory view of a module's most recent run.
+type runRecord struct {
+    startedAt  time.Time
+    finishedAt time.Time
+    err        error
+}
+
+// Runner schedules each Module on its own cron Schedule, with panic
+// recovery, a per-module timeout, and run history persisted to the
+// module_runs table.
+type Runner struct {
+    db      *database.DB
+    modules []Module
+    timeout time.Duration
+    cron    *cron.Cron
+
+    mu       sync.RWMutex
+    entryIDs map[string]cron.EntryID
+    lastRun  map[string]runRecord
+}
+
+// NewRunner creates a Runner for modules, persisting run history to db.
+func NewRunner(db *database.DB, modules []Module) *Runner {
+    return &Runner{
+        db:       db,
+        modules:  modules,
+        timeout:  defaultTimeout,
+        cron:     cron.New(),
+        entryIDs: make(map[string]cron.EntryID),
+        lastRun:  make(map[string]runRecord),
+    }
+}
+
+// Start schedules every module on its configured cron Schedule and starts
+// firing them. It does not run anything immediately; call RunAllOnce first
+// if an initial run at boot is wanted.
+func (r *Runner) Start() error {
+    for _, mod := range r.modules {
+        mod := mod
+        id, err := r.cron.AddFunc(mod.Schedule(), func() {
+            r.runOnce(context.Background(), mod)
+        })
+        if err != nil {
+            return fmt.Errorf("failed to schedule module %s: %w", mod.Name(), err)
+        }
+        r.entryIDs[mod.Name()] = id
+    }
+    r.cron.Start()
+    return nil
+}
+
+// Stop stops the cron scheduler and returns a context that's done once any
+// currently-running job has finished.
+func (r *Runner) Stop() context.Context {
+    return r.cron.Stop()
+}
+
+// RunAllOnce runs every module once, synchronously, in registration order.
+// Errors are recorded in run history but do not stop later modules from
+// running.
+func (r *Runner) RunAllOnce(ctx context.Context) {
+    for _, mod := range r.modules {
+        r.runOnce(ctx, mod)
+    }
+}
+
+// runOnce executes mod.Execute with a timeout and panic recovery, then
+// records the outcome.
+func (r *Runner) runOnce(ctx context.Context, mod Module) {
+    start := time.Now()
+    runCtx, cancel := context.WithTimeout(ctx, r.timeout)
+    defer cancel()
+
+    var runErr error
+    func() {
+        defer func() {
+            if p := recover(); p != nil {
+                runErr = fmt.Errorf("panic: %v", p)
+            }
+        }()
+        runErr = mod.Execute(runCtx)
+    }()
+
+    finished := time.Now()
+    r.recordRun(mod.Name(), start, finished, runErr)
+}
+
+func (r *Runner) recordRun(name string, started, finished time.Time, runErr error) {
+    r.mu.Lock()
+    r.lastRun[name] = runRecord{startedAt: started, finishedAt: finished, err: runErr}
+    r.mu.Unlock()
+
+    errText := ""
+    if runErr != nil {
+        errText = runErr.Error()
+    }
+    _, err := r.db.GetDB().Exec(`
+        INSERT INTO module_runs (module, started_at, finished_at, success, error)
+        VALUES (?, ?, ?, ?, ?)
+    `, name, started, finished, runErr == nil, errText)
+    if err != nil {
+        // Best effort: a failure to persist run history shouldn't take the
+        // module down, but it's worth a log line to investigate later.
+        lg := logging.With("module", name)
+        lg.Error().Err(err).Msg("failed to save run history")
+    }
+}
+
+// nextRun returns when mod is next scheduled to run.
+func (r *Runner) nextRun(name string) (time.Time, bool) {
+    r.mu.RLock()
+    id, ok := r.entryIDs[name]
+    r.mu.RUnlock()
+    if !ok {
+        return time.Time{}, false
+    }
+    return r.cron.Entry(id).Next, true
+}
+
+// RegisterCommands registers the `!modules` command, which shows each
+// module's last run, next run, and last error.
+func (r *Runner) RegisterCommands(bot *discord.Bot) {
+    bot.RegisterCommand("modules", "Show module run status", r.modulesCommand)
+}
+
+func (r *Runner) modulesCommand(s *discordgo.Session, m *discordgo.MessageCreate, g *guild.Guild, args []string) error {
+    var sb strings.Builder
+    sb.WriteString("**Modules:**\n")
+
+    for _, mod := range r.modules {
+        sb.WriteString(fmt.Sprintf("`%s` — ", mod.Name()))
+
+        r.mu.RLock()
+        last, hasLast := r.lastRun[mod.Name()]
+        r.mu.RUnlock()
+
+        if hasLast {
+            status := "ok"
+            if last.err != nil {
+                status = fmt.Sprintf("error: %v", last.err)
+            }
+            sb.WriteString(fmt.Sprintf("last run %s (%s)", last.finishedAt.Format(time.RFC3339), status))
+        } else {
+            sb.WriteString("never run")
+        }
+
+        if next, ok := r.nextRun(mod.Name()); ok {
+            sb.WriteString(fmt.Sprintf(", next run %s", next.Format(time.RFC3339)))
+        }
+        if err := mod.HealthCheck(); err != nil {
+            sb.WriteString(fmt.Sprintf(", unhealthy: %v", err))
+        }
+        sb.WriteString("\n")
+    }
+
+    _, err := s.ChannelMessageSend(m.ChannelID, sb.String())
+    return err
+}
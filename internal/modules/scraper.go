@@ -0,0 +1,60 @@
+package modules
+
+import (
+    "net/http"
+    "net/url"
+    "sync"
+
+    "rinkokonoe/internal/config"
+)
+
+// ScraperContext is shared by every module that does HTTP scraping, so they
+// all rotate through the same proxy pool and present the same user agent
+// instead of each maintaining their own.
+type ScraperContext struct {
+    UserAgent string
+
+    mu        sync.Mutex
+    proxies   []string
+    nextProxy int
+}
+
+// NewScraperContext builds a ScraperContext from the bot's configuration.
+func NewScraperContext(cfg *config.Config) *ScraperContext {
+    return &ScraperContext{
+        UserAgent: cfg.ScraperUserAgent,
+        proxies:   cfg.ProxyList,
+    }
+}
+
+// NextProxy returns the next proxy URL in round-robin order, or "" if no
+// proxies are configured.
+func (s *ScraperContext) NextProxy() string {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    if len(s.proxies) == 0 {
+        return ""
+    }
+    proxy := s.proxies[s.nextProxy]
+    s.nextProxy = (s.nextProxy + 1) % len(s.proxies)
+    return proxy
+}
+
+// Client returns an *http.Client configured to use the next proxy in
+// rotation (if any). Callers should request a fresh client per request, or
+// per batch of requests, to actually rotate.
+func (s *ScraperContext) Client() (*http.Client, error) {
+    proxy := s.NextProxy()
+    if proxy == "" {
+        return &http.Client{}, nil
+    }
+
+    proxyURL, err := url.Parse(proxy)
+    if err != nil {
+        return nil, err
+    }
+    return &http.Client{
+        Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)},
+    }, nil
+}
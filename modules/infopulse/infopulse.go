@@ -1,26 +1,40 @@
 package infopulse
 
 import (
+    "context"
+    "errors"
+    "fmt"
+
     "rinkokonoe/internal/config"
     "rinkokonoe/internal/database"
     "rinkokonoe/internal/discord"
+    "rinkokonoe/internal/guild"
+    "rinkokonoe/internal/modules"
 )
 
+func init() {
+    modules.Register("infopulse", func(deps modules.Deps) modules.Module {
+        return New(deps.DB, deps.Bot, deps.Config, deps.Guilds)
+    })
+}
+
 // Module represents the infopulse module
 type Module struct {
     name   string
     db     *database.DB
     bot    *discord.Bot
     config *config.Config
+    guilds *guild.Store
 }
 
 // New creates a new infopulse module
-func New(db *database.DB, bot *discord.Bot, cfg *config.Config) *Module {
+func New(db *database.DB, bot *discord.Bot, cfg *config.Config, guilds *guild.Store) *Module {
     return &Module{
         name:   "infopulse",
         db:     db,
         bot:    bot,
         config: cfg,
+        guilds: guilds,
     }
 }
 
@@ -34,7 +48,31 @@ func (m *Module) Schedule() string {
     return "0 */6 * * *"  // Every 6 hours by default
 }
 
-// Execute runs the module tasks
-func (m *Module) Execute() error {
+// Execute runs the module tasks for every guild that has infopulse enabled.
+// One guild's failure doesn't stop the others from running; their errors
+// are joined and returned together once the whole pass is done.
+func (m *Module) Execute(ctx context.Context) error {
+    var errs []error
+    for _, g := range m.guilds.All() {
+        if !g.ModuleEnabled(m.name) {
+            continue
+        }
+        if err := m.executeForGuild(ctx, g); err != nil {
+            errs = append(errs, fmt.Errorf("infopulse: guild %s: %w", g.ID, err))
+        }
+    }
+    return errors.Join(errs...)
+}
+
+// executeForGuild runs the module's tasks scoped to a single guild.
+func (m *Module) executeForGuild(ctx context.Context, g *guild.Guild) error {
+    return nil
+}
+
+// RegisterCommands registers infopulse's Discord commands. It has none yet.
+func (m *Module) RegisterCommands(bot *discord.Bot) {}
+
+// HealthCheck reports whether the module is in a usable state.
+func (m *Module) HealthCheck() error {
     return nil
 }